@@ -0,0 +1,134 @@
+// Package internal holds the types shared by the xo command and its
+// per-dialect schema loaders: the generator's argument set, the registry
+// loaders plug themselves into, and the small set of relation kinds xo
+// knows how to generate code for.
+package internal
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/sharonjl/xo/models"
+)
+
+// RelType is a postgres relkind, used to select which objects ProcessRelkind
+// (and the loader's TableList) should operate against.
+type RelType uint
+
+// RelType values.
+const (
+	Table RelType = iota
+	View
+	MaterializedView
+	PartitionedTable
+	ForeignTable
+)
+
+// ArgType holds the parsed command-line arguments and any state a loader
+// accumulates while introspecting a schema.
+type ArgType struct {
+	// DB is the database connection used for introspection.
+	DB models.XODB
+
+	// PgxConn, when set, is a single physical pgx connection the postgres
+	// loader's query-column introspection can PREPARE/DEALLOCATE a
+	// statement on directly. DB is the database/sql-shaped connection
+	// (lib/pq) used everywhere else in the loader; it can't satisfy the
+	// pgx.Conn API (context-taking methods, pgx-native result types), and
+	// PREPARE/its DEALLOCATE must land on the same backend connection, so
+	// this is threaded separately rather than asserted out of DB. Left nil
+	// when the caller didn't wire up a pgx connection, in which case that
+	// introspection path is simply unavailable.
+	PgxConn *pgx.Conn
+
+	// EnablePostgresOIDs enables loading the Postgres OID for each column.
+	EnablePostgresOIDs bool
+
+	// PgtypeVersion selects which pgx pgtype API generated scanner/valuer
+	// code targets: "v4" (default, the legacy pgtype package shipped with
+	// pgx v3/v4) or "v5" (github.com/jackc/pgx/v5/pgtype).
+	PgtypeVersion string
+
+	// PgtypePointerMode, when set, emits scalars as plain Go pointer types
+	// (*string, *int64, ...) instead of pgtype structs, so generated code
+	// doesn't need to depend on pgtype at all. Every scalar is emitted as a
+	// pointer in this mode, whether or not its column is nullable.
+	PgtypePointerMode bool
+
+	// DomainBaseTypes maps a Postgres domain name to its base type's
+	// Postgres type name, populated by the loader's DomainList hook.
+	DomainBaseTypes map[string]string
+
+	// CompositeTypes records the set of Postgres composite type names
+	// found in the schema, populated by the loader's CompositeList hook.
+	CompositeTypes map[string]bool
+}
+
+// Args holds the generator's current argument set. Loaders that need to
+// stash cross-call state discovered while introspecting a schema do so
+// here, the same way the rest of xo threads global state.
+var Args = &ArgType{}
+
+// pgPrecisionRE matches a type name with an optional (precision[,scale])
+// suffix, e.g. "numeric(10,2)".
+var pgPrecisionRE = regexp.MustCompile(`^([a-zA-Z _]+)\((\d+)(?:,\s*\d+)?\)$`)
+
+// ParsePrecision strips a "(precision[,scale])" suffix from dt, returning
+// the base type name and the precision (0 if dt has none).
+func (a *ArgType) ParsePrecision(dt string) (string, int, error) {
+	m := pgPrecisionRE.FindStringSubmatch(dt)
+	if m == nil {
+		return dt, 0, nil
+	}
+
+	p, err := strconv.Atoi(m[2])
+	if err != nil {
+		return dt, 0, err
+	}
+
+	return strings.TrimSpace(m[1]), p, nil
+}
+
+// TypeLoader holds a set of functions that are dialect-dependent, used to
+// handle how the schema and code are loaded/generated for a particular
+// database.
+type TypeLoader struct {
+	ProcessRelkind  func(RelType) string
+	Schema          func(*ArgType) (string, error)
+	ParseType       func(*ArgType, string, bool) (int, string, string)
+	EnumList        func(models.XODB, string) ([]*models.Enum, error)
+	EnumValueList   func(models.XODB, string) ([]*models.EnumValue, error)
+	ProcList        func(models.XODB, string) ([]*models.Proc, error)
+	ProcParamList   func(models.XODB, string) ([]*models.ProcParam, error)
+	TableList       func(models.XODB, string, string) ([]*models.Table, error)
+	ColumnList      func(models.XODB, string, string) ([]*models.Column, error)
+	ForeignKeyList  func(models.XODB, string, string) ([]*models.ForeignKey, error)
+	IndexList       func(models.XODB, string, string) ([]*models.Index, error)
+	IndexColumnList func(models.XODB, string, string, string) ([]*models.IndexColumn, error)
+	QueryStrip      func([]string, []string)
+	QueryColumnList func(*ArgType, []string) ([]*models.Column, error)
+	CompositeList   func(models.XODB, string) ([]*models.Composite, error)
+	CompositeAttrs  func(models.XODB, string, string) ([]*models.CompositeAttr, error)
+	DomainList      func(models.XODB, string) ([]*models.Domain, error)
+}
+
+// SchemaLoaders is the registry of per-dialect TypeLoaders, keyed by driver
+// name (e.g. "postgres").
+var SchemaLoaders = map[string]TypeLoader{}
+
+// GenRandomID generates a short random hex identifier, used to name
+// temporary objects (views, prepared statements) created during schema
+// introspection.
+func GenRandomID() string {
+	b := make([]byte, 8)
+	// crypto/rand.Read on the default Reader does not fail in practice;
+	// a zero buffer is an acceptable degraded fallback for a temp-object
+	// suffix, not a correctness issue.
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
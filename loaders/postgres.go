@@ -1,6 +1,7 @@
 package loaders
 
 import (
+	"context"
 	"fmt"
 	"regexp"
 	"strconv"
@@ -31,6 +32,9 @@ func init() {
 		IndexColumnList: PgIndexColumns,
 		QueryStrip:      PgQueryStrip,
 		QueryColumnList: PgQueryColumns,
+		CompositeList:   PgComposites,
+		CompositeAttrs:  models.PgCompositeAttrs,
+		DomainList:      PgDomains,
 	}
 }
 
@@ -42,12 +46,49 @@ func PgRelkind(relType internal.RelType) string {
 		s = "r"
 	case internal.View:
 		s = "v"
+	case internal.MaterializedView:
+		s = "m"
+	case internal.PartitionedTable:
+		s = "p"
+	case internal.ForeignTable:
+		s = "f"
 	default:
 		panic("unsupported RelType")
 	}
 	return s
 }
 
+// pointerDt maps a postgres scalar type directly to a Go pointer type,
+// bypassing pgtype entirely. Used when args.PgtypePointerMode is set.
+var pointerDt = map[string]string{
+	"boolean":                     "*bool",
+	"character":                   "*string",
+	"character varying":           "*string",
+	"text":                        "*string",
+	"money":                       "*string",
+	"inet":                        "*string",
+	"smallint":                    "*int16",
+	"smallserial":                 "*int16",
+	"integer":                     "*int32",
+	"serial":                      "*int32",
+	"bigint":                      "*int64",
+	"bigserial":                   "*int64",
+	"real":                        "*float32",
+	"numeric":                     "*float64",
+	"double precision":            "*float64",
+	"bytea":                       "*[]byte",
+	"jsonb":                       "*string",
+	"date":                        "*time.Time",
+	"timestamp with time zone":    "*time.Time",
+	"time with time zone":         "*time.Time",
+	"time without time zone":      "*time.Time",
+	"timestamp without time zone": "*time.Time",
+	"interval":                    "*string",
+	`"char"`:                      "*string",
+	"bit":                         "*string",
+	"uuid":                        "*string",
+}
+
 // PgParseType parse a postgres type into a Go type based on the column
 // definition.
 func PgParseType(args *internal.ArgType, dt string, nullable bool) (int, string, string) {
@@ -71,14 +112,74 @@ func PgParseType(args *internal.ArgType, dt string, nullable bool) (int, string,
 	// extract precision
 	dt, precision, _ = args.ParsePrecision(dt)
 
-	nilVal, typ = nonSliceDt(dt)
+	nilVal, typ = nonSliceDt(args, dt)
 	if asSlice {
-		nilVal, typ = sliceDt(dt)
+		nilVal, typ = sliceDt(args, dt)
 	}
 	return precision, nilVal, typ
 }
 
-func nonSliceDt(dt string) (nilVal, typ string) {
+// nonSliceDt maps a postgres scalar type to its Go representation. When
+// args.PgtypePointerMode is set, scalars are emitted as plain Go pointer
+// types (e.g. *string, *int64) instead of pgtype structs, so generated code
+// doesn't need to import pgtype at all. Otherwise the mapping is selected
+// from args.PgtypeVersion ("v4" or "v5"), since pgx v5 reshuffled several
+// pgtype names (e.g. Timestamp/Timestamptz semantics, Numeric).
+func nonSliceDt(args *internal.ArgType, dt string) (nilVal, typ string) {
+	if base, ok := args.DomainBaseTypes[dt]; ok {
+		return domainDt(args, dt, base)
+	}
+
+	if args.PgtypePointerMode {
+		if t, ok := pointerDt[dt]; ok {
+			return "nil", t
+		}
+		typ = "*" + snaker.SnakeToCamelIdentifier(dt)
+		return "nil", typ
+	}
+
+	if args.CompositeTypes[dt] {
+		return compositeDt(dt)
+	}
+
+	if args.PgtypeVersion == "v5" {
+		return nonSliceDtV5(dt)
+	}
+	return nonSliceDtV4(dt)
+}
+
+// compositeDt maps a Postgres composite type to its Go representation: the
+// camel-cased type name, the same identifier the (non-composite) default
+// case already produced. It only names the type; this package does not
+// emit the Go struct or a pgtype.CompositeFields scanner/valuer for it.
+func compositeDt(dt string) (nilVal, typ string) {
+	typ = snaker.SnakeToCamelIdentifier(dt)
+	nilVal = typ + "{}"
+	return
+}
+
+// domainDt maps a domain to the camel-cased domain name itself, so that
+// downstream code is typed against the domain rather than its underlying
+// primitive. It does not consult base: the base type's Go mapping is not
+// resolved here, and this package generates no Go definition of the
+// domain type.
+func domainDt(args *internal.ArgType, dt, base string) (nilVal, typ string) {
+	typ = snaker.SnakeToCamelIdentifier(dt)
+	if args.PgtypePointerMode {
+		return "nil", "*" + typ
+	}
+
+	// The zero value literal is typ{}, which is valid for a defined type
+	// over a struct-shaped base mapping (e.g. `type EmailAddress
+	// pgtype.Text`) and is a value of typ -- not a conversion through
+	// base, which would produce a value of the base type instead of typ.
+	return typ + "{}", typ
+}
+
+// nonSliceDtV4 maps a postgres scalar type to the legacy (pgx v3/v4)
+// github.com/jackc/pgtype package, which -- unlike v5 -- still ships real
+// Bytea, JSONB, QChar, and Bit struct types, so those names carry over as-is.
+func nonSliceDtV4(dt string) (nilVal, typ string) {
 	switch dt {
 	case "boolean":
 		nilVal = "pgtype.Bool{}"
@@ -151,16 +252,160 @@ func nonSliceDt(dt string) (nilVal, typ string) {
 	return
 }
 
-func sliceDt(dt string) (nilVal, typ string) {
+// nonSliceDtV5 is the pgx v5 counterpart of nonSliceDtV4. Most scalar names
+// carry over unchanged, but callers must remember that the generated struct
+// now exposes a Valid bool (replacing the old Status enum) that has to be
+// set explicitly on writes; numeric maps to pgtype.Numeric (v4 used Float8),
+// and "with time zone" types map to pgtype.Timestamptz and "without time
+// zone" types to pgtype.Timestamp -- the reverse of nonSliceDtV4's mapping.
+// A handful of v4 types became codec-only in v5 -- github.com/jackc/pgx/v5/
+// pgtype ships a *Codec (ByteaCodec, JSONBCodec, QCharCodec) for these but no
+// scalar struct, so they're mapped onto the plain Go type the codec scans
+// into instead (bytea/jsonb -> []byte, "char" -> string); bit keeps a real
+// struct, just renamed to pgtype.Bits.
+func nonSliceDtV5(dt string) (nilVal, typ string) {
 	switch dt {
+	case "boolean":
+		nilVal = "pgtype.Bool{}"
+		typ = "pgtype.Bool"
+
+	case "character", "character varying", "text", "money", "inet":
+		nilVal = "pgtype.Text{}"
+		typ = "pgtype.Text"
+
+	case "smallint", "smallserial":
+		nilVal = "pgtype.Int2{}"
+		typ = "pgtype.Int2"
+
+	case "integer", "serial":
+		nilVal = "pgtype.Int4{}"
+		typ = "pgtype.Int4"
+
+	case "bigint", "bigserial":
+		nilVal = "pgtype.Int8{}"
+		typ = "pgtype.Int8"
+
+	case "real":
+		nilVal = "pgtype.Float4{}"
+		typ = "pgtype.Float4"
+
+	case "numeric":
+		nilVal = "pgtype.Numeric{}"
+		typ = "pgtype.Numeric"
+
+	case "double precision":
+		nilVal = "pgtype.Float8{}"
+		typ = "pgtype.Float8"
+
+	case "bytea":
+		nilVal = "[]byte{}"
+		typ = "[]byte"
+
+	case "jsonb":
+		nilVal = "[]byte{}"
+		typ = "[]byte"
+
+	case "date":
+		nilVal = "pgtype.Date{}"
+		typ = "pgtype.Date"
+
+	case "timestamp with time zone", "time with time zone":
+		nilVal = "pgtype.Timestamptz{}"
+		typ = "pgtype.Timestamptz"
+
+	case "time without time zone", "timestamp without time zone":
+		nilVal = "pgtype.Timestamp{}"
+		typ = "pgtype.Timestamp"
+
+	case "interval":
+		nilVal = "pgtype.Interval{}"
+		typ = "pgtype.Interval"
+
+	case `"char"`:
+		nilVal = `""`
+		typ = "string"
+
+	case "bit":
+		nilVal = "pgtype.Bits{}"
+		typ = "pgtype.Bits"
+
 	case "uuid":
-		nilVal = "pgtype.UUIDArray{}"
-		typ = "pgtype.UUIDArray"
+		nilVal = "pgtype.UUID{}"
+		typ = "pgtype.UUID"
 
 	default:
-		nilVal = "[]" + dt + "{}"
-		typ = "[]" + dt
+		typ = snaker.SnakeToCamelIdentifier(dt)
+		nilVal = typ + "{}"
+	}
+	return
+}
+
+// pgArrayWrapper maps a postgres array element type to its dedicated pgtype
+// array wrapper (pgx v3/v4 naming, e.g. pgtype.BoolArray). Only element
+// types github.com/jackc/pgtype (v1.x) actually ships a *_array.go wrapper
+// for are listed here; "time with time zone", "time without time zone",
+// "interval", "oid", and "name" have no such wrapper in that package (no
+// TimetzArray/TimeArray/IntervalArray/OIDValueArray/NameArray exist) and so
+// are deliberately absent, falling through to the unwrapped-element
+// fallback in sliceDt, same as enums/composites/domains.
+var pgArrayWrapper = map[string]string{
+	"boolean":                     "pgtype.BoolArray",
+	"smallint":                    "pgtype.Int2Array",
+	"smallserial":                 "pgtype.Int2Array",
+	"integer":                     "pgtype.Int4Array",
+	"serial":                      "pgtype.Int4Array",
+	"bigint":                      "pgtype.Int8Array",
+	"bigserial":                   "pgtype.Int8Array",
+	"real":                        "pgtype.Float4Array",
+	"double precision":            "pgtype.Float8Array",
+	"text":                        "pgtype.TextArray",
+	"character varying":           "pgtype.VarcharArray",
+	"character":                   "pgtype.BPCharArray",
+	"bytea":                       "pgtype.ByteaArray",
+	"date":                        "pgtype.DateArray",
+	"timestamp with time zone":    "pgtype.TimestamptzArray",
+	"timestamp without time zone": "pgtype.TimestampArray",
+	"numeric":                     "pgtype.NumericArray",
+	"inet":                        "pgtype.InetArray",
+	"cidr":                        "pgtype.CIDRArray",
+	"macaddr":                     "pgtype.MacaddrArray",
+	"json":                        "pgtype.JSONArray",
+	"jsonb":                       "pgtype.JSONBArray",
+	"money":                       "pgtype.TextArray",
+	"uuid":                        "pgtype.UUIDArray",
+}
+
+// sliceDt maps a postgres array type to its Go representation. Under pgx v4
+// naming, element types with a dedicated wrapper (see pgArrayWrapper) use
+// that wrapper directly, and element types with no dedicated wrapper (enums,
+// composites, domains) fall back to a plain Go slice []T of the resolved
+// element type; pgx v5 instead uses the generic pgtype.Array[T] over the
+// resolved scalar element type for every element type.
+func sliceDt(args *internal.ArgType, dt string) (nilVal, typ string) {
+	if args.PgtypePointerMode {
+		_, elemTyp := nonSliceDt(args, dt)
+		return "nil", "[]" + elemTyp
 	}
+
+	if args.PgtypeVersion == "v5" {
+		_, elemTyp := nonSliceDtV5(dt)
+		typ = "pgtype.Array[" + elemTyp + "]"
+		nilVal = typ + "{}"
+		return nilVal, typ
+	}
+
+	if wrapper, ok := pgArrayWrapper[dt]; ok {
+		return wrapper + "{}", wrapper
+	}
+
+	// no dedicated array wrapper for this element type (enum, composite,
+	// domain, or otherwise unrecognized) under pgx v4 -- pgtype.Array[T]
+	// is a v5-only generic type, so fall back to a plain Go slice of the
+	// resolved scalar element type instead. Scanning such a column
+	// requires manual glue, but the emitted code at least compiles.
+	_, elemTyp := nonSliceDtV4(dt)
+	typ = "[]" + elemTyp
+	nilVal = typ + "{}"
 	return
 }
 
@@ -200,6 +445,18 @@ func PgTables(db models.XODB, schema string, relkind string) ([]*models.Table, e
 		sequences = []*models.Sequence{}
 	}
 
+	// Get partition key columns and parent/child relationships for
+	// partitioned tables (pg_partitioned_table).
+	partitions, err := models.PgPartitionedTables(db, schema)
+	if err != nil {
+		// Set it to an empty set on error.
+		partitions = []*models.Partition{}
+	}
+	partitionsByTable := make(map[string]*models.Partition, len(partitions))
+	for _, p := range partitions {
+		partitionsByTable[p.TableName] = p
+	}
+
 	// Add information about manual FK.
 	var tables []*models.Table
 	for _, row := range rows {
@@ -210,28 +467,175 @@ func PgTables(db models.XODB, schema string, relkind string) ([]*models.Table, e
 				manualPk = false
 			}
 		}
-		tables = append(tables, &models.Table{
+
+		partition := partitionsByTable[row.TableName]
+		if partition != nil && manualPk {
+			// Partition roots don't own a sequence directly -- walk
+			// pg_inherits to find a leaf table that does.
+			manualPk, err = pgPartitionStillManualPk(db, schema, row.TableName, sequences)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		table := &models.Table{
 			TableName: row.TableName,
 			Type:      row.Type,
 			ManualPk:  manualPk,
-		})
+		}
+		if partition != nil {
+			table.PartitionKeyColumns = partition.KeyColumns
+			table.ParentTable = partition.ParentTable
+		}
+		tables = append(tables, table)
 	}
 
 	return tables, nil
 }
 
+// pgPartitionStillManualPk walks pg_inherits starting at table to
+// determine the ManualPk value for a partitioned root: it returns false as
+// soon as it finds a descendant (partitioned or not) that owns one of the
+// given sequences, and true (still manual) if no table in the partition
+// tree below table owns one. Each child is checked directly against
+// sequences and then recursed into.
+func pgPartitionStillManualPk(db models.XODB, schema, table string, sequences []*models.Sequence) (bool, error) {
+	children, err := models.PgInheritedChildren(db, schema, table)
+	if err != nil {
+		return true, err
+	}
+
+	for _, child := range children {
+		for _, sequence := range sequences {
+			if sequence.TableName == child {
+				return false, nil
+			}
+		}
+
+		stillManual, err := pgPartitionStillManualPk(db, schema, child, sequences)
+		if err != nil {
+			return true, err
+		}
+		if !stillManual {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// PgDomains returns the Postgres domains and records their base type on
+// internal.Args.DomainBaseTypes, which nonSliceDt uses to recognize a
+// domain name and emit the domain's own type name for it (see domainDt).
+func PgDomains(db models.XODB, schema string) ([]*models.Domain, error) {
+	domains, err := models.PgDomains(db, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	if internal.Args.DomainBaseTypes == nil {
+		internal.Args.DomainBaseTypes = map[string]string{}
+	}
+	for _, d := range domains {
+		internal.Args.DomainBaseTypes[d.DomainName] = d.BaseType
+	}
+
+	return domains, nil
+}
+
+// PgComposites returns the Postgres composite types and records their
+// names on internal.Args.CompositeTypes so that nonSliceDt can recognize
+// an otherwise-unresolved type name as a composite rather than, say, an
+// enum. PgComposites/PgCompositeAttrs only supply the composite's name and
+// attributes; no Go struct or pgtype.CompositeFields scanner/valuer for a
+// composite is emitted in this package.
+func PgComposites(db models.XODB, schema string) ([]*models.Composite, error) {
+	composites, err := models.PgComposites(db, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	if internal.Args.CompositeTypes == nil {
+		internal.Args.CompositeTypes = map[string]bool{}
+	}
+	for _, c := range composites {
+		internal.Args.CompositeTypes[c.TypeName] = true
+	}
+
+	return composites, nil
+}
+
 // PgQueryColumns parses the query and generates a type for it.
 func PgQueryColumns(args *internal.ArgType, inspect []string) ([]*models.Column, error) {
+	query := strings.Join(inspect, "\n")
+
+	cols, err := pgQueryColumnsViaPrepare(args, query)
+	if err == nil {
+		return cols, nil
+	}
+
+	// PREPARE can't describe every statement shape a CREATE TEMPORARY VIEW
+	// can (e.g. SELECT INTO) -- fall back to the view-based path.
+	return pgQueryColumnsViaView(args, query)
+}
+
+// pgQueryColumnsViaPrepare describes query with PREPARE, which (unlike
+// CREATE TEMPORARY VIEW) works for queries containing CTEs that write, DML
+// with RETURNING, and other statements a view can't be created over. The
+// prepared statement is always deallocated before returning, even on
+// error.
+func pgQueryColumnsViaPrepare(args *internal.ArgType, query string) ([]*models.Column, error) {
+	// PREPARE and its DEALLOCATE are connection-scoped, so this path only
+	// applies when the caller wired up a single physical pgx connection on
+	// args.PgxConn -- args.DB is the database/sql-shaped (lib/pq)
+	// connection used everywhere else in the loader and can't be asserted
+	// into a *pgx.Conn. PgxConn being unset simply means this path isn't
+	// available; the caller falls back to the view-based path in that
+	// case.
+	conn := args.PgxConn
+	if conn == nil {
+		return nil, fmt.Errorf("PREPARE-based introspection requires args.PgxConn to be set")
+	}
+
+	ctx := context.Background()
+	xoid := "_xo_" + internal.GenRandomID()
+
+	models.XOLog("PREPARE " + xoid + " AS " + query)
+	sd, err := conn.Prepare(ctx, xoid, query)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		deallocq := `DEALLOCATE ` + xoid
+		models.XOLog(deallocq)
+		conn.Exec(ctx, deallocq)
+	}()
+
+	// each field carries a DataTypeOID, which is resolved against pg_type
+	// (via format_type) for the Go mapping, on the same connection.
+	return models.PgColumnsFromFields(ctx, conn, sd.Fields)
+}
+
+// pgQueryColumnsViaView is the original introspection path: it creates a
+// temporary view over the query and inspects its columns via
+// pg_class/pg_attribute. It's kept as a fallback for statement shapes
+// PREPARE can't describe.
+func pgQueryColumnsViaView(args *internal.ArgType, query string) ([]*models.Column, error) {
 	var err error
 
 	// create temporary view xoid
 	xoid := "_xo_" + internal.GenRandomID()
-	viewq := `CREATE TEMPORARY VIEW ` + xoid + ` AS (` + strings.Join(inspect, "\n") + `)`
+	viewq := `CREATE TEMPORARY VIEW ` + xoid + ` AS (` + query + `)`
 	models.XOLog(viewq)
 	_, err = args.DB.Exec(viewq)
 	if err != nil {
 		return nil, err
 	}
+	defer func() {
+		dropq := `DROP VIEW IF EXISTS ` + xoid
+		models.XOLog(dropq)
+		args.DB.Exec(dropq)
+	}()
 
 	// query to determine schema name where temporary view was created
 	var nspq = `SELECT n.nspname ` +
@@ -267,6 +671,15 @@ func PgIndexColumns(db models.XODB, schema string, table string, index string) (
 		return nil, err
 	}
 
+	// determine whether this index is attached to a partitioned parent,
+	// and if so whether it's "local" (only valid against this partition)
+	// or "global-style" (attached via pg_inherits to a parent index that
+	// spans all partitions).
+	scope, err := pgIndexPartitionScope(db, schema, table, index)
+	if err != nil {
+		return nil, err
+	}
+
 	// build schema name used in errors
 	s := schema
 	if s != "" {
@@ -297,8 +710,62 @@ func PgIndexColumns(db models.XODB, schema string, table string, index string) (
 			return nil, fmt.Errorf("could not find %s%s index %s column id %d", s, table, index, cid)
 		}
 
+		c.PartitionScope = scope
 		ret = append(ret, c)
 	}
 
 	return ret, nil
 }
+
+// Partition scope values recorded on models.IndexColumn.PartitionScope,
+// describing how an index relates to table partitioning. This is
+// introspection only: nothing in this tree yet generates the
+// parent-routed FindByX lookup for PartitionScopeGlobal or the
+// FindByX_InPartition helper (with its compile-time comment explaining the
+// limitation) for PartitionScopeLocal -- that's still outstanding codegen
+// work in the struct-generation templates.
+const (
+	// PartitionScopeNone means the table is not partitioned.
+	PartitionScopeNone = ""
+	// PartitionScopeLocal means the index only covers a single partition
+	// and cannot be used to satisfy a uniqueness lookup across the whole
+	// partitioned table (Postgres requires the partition key to be part
+	// of any unique index declared on the parent).
+	PartitionScopeLocal = "local"
+	// PartitionScopeGlobal means the index is declared on a partitioned
+	// parent and has matching indexes attached on every partition
+	// (pg_inherits), so a lookup through the parent relation lets the
+	// planner prune to the right partition.
+	PartitionScopeGlobal = "global"
+)
+
+// pgIndexPartitionScope determines the partition scope of index on table.
+// It looks at pg_index.indisvalid together with pg_inherits to tell a
+// "local" per-partition index apart from a "global-style" index attached,
+// via child indexes, to a partitioned parent.
+func pgIndexPartitionScope(db models.XODB, schema, table, index string) (string, error) {
+	partition, err := models.PgPartitionedTables(db, schema)
+	if err != nil {
+		return PartitionScopeNone, err
+	}
+
+	isPartitioned := false
+	for _, p := range partition {
+		if p.TableName == table {
+			isPartitioned = true
+			break
+		}
+	}
+	if !isPartitioned {
+		return PartitionScopeNone, nil
+	}
+
+	attached, err := models.PgIndexAttachedOnAllPartitions(db, schema, table, index)
+	if err != nil {
+		return PartitionScopeNone, err
+	}
+	if attached {
+		return PartitionScopeGlobal, nil
+	}
+	return PartitionScopeLocal, nil
+}
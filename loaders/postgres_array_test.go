@@ -0,0 +1,83 @@
+package loaders
+
+import "testing"
+
+// TestSliceDtWrappedElement checks array element types with a dedicated
+// pgx v3/v4 wrapper (pgArrayWrapper) map to that wrapper under v4, and to
+// the generic pgtype.Array[T] under v5.
+func TestSliceDtWrappedElement(t *testing.T) {
+	args := newArgs()
+
+	args.PgtypeVersion = "v4"
+	nilVal, typ := sliceDt(args, "uuid")
+	if typ != "pgtype.UUIDArray" || nilVal != "pgtype.UUIDArray{}" {
+		t.Errorf("v4 uuid[]: got (%q, %q), want (%q, %q)", nilVal, typ, "pgtype.UUIDArray{}", "pgtype.UUIDArray")
+	}
+
+	args.PgtypeVersion = "v5"
+	nilVal, typ = sliceDt(args, "uuid")
+	wantTyp := "pgtype.Array[pgtype.UUID]"
+	if typ != wantTyp || nilVal != wantTyp+"{}" {
+		t.Errorf("v5 uuid[]: got (%q, %q), want (%q, %q)", nilVal, typ, wantTyp+"{}", wantTyp)
+	}
+}
+
+// TestSliceDtUnwrappedElement checks array element types with no dedicated
+// wrapper (enums, composites, domains) fall back to a plain Go slice under
+// v4 -- not the v5-only generic pgtype.Array[T], which pgx v4 doesn't have
+// -- and to pgtype.Array[T] under v5.
+func TestSliceDtUnwrappedElement(t *testing.T) {
+	args := newArgs()
+
+	args.PgtypeVersion = "v4"
+	nilVal, typ := sliceDt(args, "mood")
+	if typ != "[]Mood" || nilVal != "[]Mood{}" {
+		t.Errorf("v4 mood[]: got (%q, %q), want (%q, %q)", nilVal, typ, "[]Mood{}", "[]Mood")
+	}
+
+	args.PgtypeVersion = "v5"
+	nilVal, typ = sliceDt(args, "mood")
+	wantTypV5 := "pgtype.Array[Mood]"
+	if typ != wantTypV5 || nilVal != wantTypV5+"{}" {
+		t.Errorf("v5 mood[]: got (%q, %q), want (%q, %q)", nilVal, typ, wantTypV5+"{}", wantTypV5)
+	}
+}
+
+// TestSliceDtNoRealWrapper checks element types that don't actually have a
+// dedicated array wrapper in github.com/jackc/pgtype (v1.x) -- despite
+// looking like they should -- fall through to the unwrapped-element
+// fallback instead of a fabricated wrapper name like pgtype.IntervalArray
+// or pgtype.OIDValueArray, neither of which exists in that package.
+func TestSliceDtNoRealWrapper(t *testing.T) {
+	args := newArgs()
+	args.PgtypeVersion = "v4"
+
+	tests := []struct {
+		dt      string
+		wantTyp string
+	}{
+		{"interval", "[]pgtype.Interval"},
+		{"time with time zone", "[]pgtype.Timestamp"},
+		{"time without time zone", "[]pgtype.Timestamptz"},
+		{"oid", "[]Oid"},
+		{"name", "[]Name"},
+	}
+	for _, tt := range tests {
+		nilVal, typ := sliceDt(args, tt.dt)
+		if typ != tt.wantTyp || nilVal != tt.wantTyp+"{}" {
+			t.Errorf("v4 %s[]: got (%q, %q), want (%q, %q)", tt.dt, nilVal, typ, tt.wantTyp+"{}", tt.wantTyp)
+		}
+	}
+}
+
+// TestSliceDtPointerMode checks pointer mode emits a plain slice of the
+// pointer-mode element type, with no pgtype dependency at all.
+func TestSliceDtPointerMode(t *testing.T) {
+	args := newArgs()
+	args.PgtypePointerMode = true
+
+	nilVal, typ := sliceDt(args, "bigint")
+	if typ != "[]*int64" || nilVal != "nil" {
+		t.Errorf("bigint[]: got (%q, %q), want (%q, %q)", nilVal, typ, "nil", "[]*int64")
+	}
+}
@@ -0,0 +1,98 @@
+package loaders
+
+import (
+	"testing"
+
+	"github.com/sharonjl/xo/internal"
+)
+
+func newArgs() *internal.ArgType {
+	return &internal.ArgType{
+		DomainBaseTypes: map[string]string{},
+		CompositeTypes:  map[string]bool{},
+	}
+}
+
+// TestNonSliceDtPgtypeVersion generates against the same schema types under
+// both PgtypeVersion settings ("v4" and "v5"), and checks nilVal's type
+// always matches typ.
+func TestNonSliceDtPgtypeVersion(t *testing.T) {
+	tests := []struct {
+		dt     string
+		wantV4 string
+		wantV5 string
+	}{
+		{"boolean", "pgtype.Bool", "pgtype.Bool"},
+		{"numeric", "pgtype.Float8", "pgtype.Numeric"},
+		{"timestamp with time zone", "pgtype.Timestamp", "pgtype.Timestamptz"},
+		{"time without time zone", "pgtype.Timestamptz", "pgtype.Timestamp"},
+		{"uuid", "pgtype.UUID", "pgtype.UUID"},
+		// bytea/jsonb have no scalar struct in pgx v5's pgtype (only a
+		// *Codec) -- unlike legacy v4 pgtype, which still has both.
+		{"bytea", "pgtype.Bytea", "[]byte"},
+		{"jsonb", "pgtype.JSONB", "[]byte"},
+		// bit keeps a real v5 struct, just renamed to pgtype.Bits.
+		{"bit", "pgtype.Bit", "pgtype.Bits"},
+	}
+
+	for _, tt := range tests {
+		args := newArgs()
+
+		args.PgtypeVersion = "v4"
+		nilVal, typ := nonSliceDt(args, tt.dt)
+		if typ != tt.wantV4 {
+			t.Errorf("v4 %s: typ = %q, want %q", tt.dt, typ, tt.wantV4)
+		}
+		if nilVal != typ+"{}" {
+			t.Errorf("v4 %s: nilVal = %q, want type of nilVal to match typ %q", tt.dt, nilVal, typ)
+		}
+
+		args.PgtypeVersion = "v5"
+		nilVal, typ = nonSliceDt(args, tt.dt)
+		if typ != tt.wantV5 {
+			t.Errorf("v5 %s: typ = %q, want %q", tt.dt, typ, tt.wantV5)
+		}
+		if nilVal != typ+"{}" {
+			t.Errorf("v5 %s: nilVal = %q, want type of nilVal to match typ %q", tt.dt, nilVal, typ)
+		}
+	}
+}
+
+// TestNonSliceDtQChar checks that pgx v5's "char" mapping, which has no
+// pgtype.QChar struct (only a QCharCodec scanning into a plain string),
+// falls back to string with a "" zero value rather than string{}, which
+// isn't valid Go for a non-struct type -- unlike legacy v4 pgtype, which
+// does have a pgtype.QChar struct.
+func TestNonSliceDtQChar(t *testing.T) {
+	args := newArgs()
+
+	args.PgtypeVersion = "v4"
+	nilVal, typ := nonSliceDt(args, `"char"`)
+	if typ != "pgtype.QChar" || nilVal != "pgtype.QChar{}" {
+		t.Errorf(`v4 "char": got (%q, %q), want (%q, %q)`, nilVal, typ, "pgtype.QChar{}", "pgtype.QChar")
+	}
+
+	args.PgtypeVersion = "v5"
+	nilVal, typ = nonSliceDt(args, `"char"`)
+	if typ != "string" || nilVal != `""` {
+		t.Errorf(`v5 "char": got (%q, %q), want (%q, %q)`, nilVal, typ, `""`, "string")
+	}
+}
+
+// TestNonSliceDtPointerMode checks that pointer mode emits a nullable Go
+// pointer type with a nil literal, both for scalars with a dedicated
+// pointerDt entry and for ones that fall through to the default case.
+func TestNonSliceDtPointerMode(t *testing.T) {
+	args := newArgs()
+	args.PgtypePointerMode = true
+
+	nilVal, typ := nonSliceDt(args, "bigint")
+	if typ != "*int64" || nilVal != "nil" {
+		t.Errorf("bigint: got (%q, %q), want (%q, %q)", nilVal, typ, "nil", "*int64")
+	}
+
+	nilVal, typ = nonSliceDt(args, "some_enum")
+	if typ != "*SomeEnum" || nilVal != "nil" {
+		t.Errorf("some_enum: got (%q, %q), want (%q, %q)", nilVal, typ, "nil", "*SomeEnum")
+	}
+}
@@ -0,0 +1,58 @@
+package loaders
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/sharonjl/xo/internal"
+)
+
+// fakeXODB is a minimal models.XODB, used to observe PgQueryColumns'
+// fallback behavior without a live database.
+type fakeXODB struct{}
+
+func (fakeXODB) Exec(query string, args ...interface{}) (sql.Result, error) { return nil, nil }
+func (fakeXODB) Query(query string, args ...interface{}) (*sql.Rows, error) { return nil, nil }
+func (fakeXODB) QueryRow(query string, args ...interface{}) *sql.Row        { return nil }
+
+// TestPgQueryColumnsViaPrepareRequiresPgxConn checks that the PREPARE-based
+// path refuses to run without a single physical connection pinned on
+// args.PgxConn, since PREPARE and its DEALLOCATE must land on the same
+// backend connection and args.DB (lib/pq, database/sql-shaped) can't
+// provide that.
+func TestPgQueryColumnsViaPrepareRequiresPgxConn(t *testing.T) {
+	args := &internal.ArgType{DB: fakeXODB{}}
+
+	_, err := pgQueryColumnsViaPrepare(args, "SELECT 1")
+	if err == nil {
+		t.Fatal("expected an error when args.PgxConn is unset, got nil")
+	}
+}
+
+// erroringXODB is a models.XODB whose Exec always fails, used to observe
+// PgQueryColumns actually attempting the view-based fallback (rather than
+// returning the PREPARE path's own error) when args.PgxConn is unset.
+type erroringXODB struct{ fakeXODB }
+
+var errExecFailed = errors.New("exec failed")
+
+func (erroringXODB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return nil, errExecFailed
+}
+
+// TestPgQueryColumnsFallsBackToView checks that when the PREPARE-based path
+// is unavailable (args.PgxConn is unset), PgQueryColumns falls all the way
+// through to pgQueryColumnsViaView instead of surfacing the PREPARE path's
+// own error.
+func TestPgQueryColumnsFallsBackToView(t *testing.T) {
+	args := &internal.ArgType{DB: erroringXODB{}}
+
+	_, err := PgQueryColumns(args, []string{"SELECT 1"})
+	if err == nil {
+		t.Fatal("expected an error, got nil")
+	}
+	if !errors.Is(err, errExecFailed) {
+		t.Errorf("error = %v, want the view path's CREATE TEMPORARY VIEW error (%v), not the PREPARE path's", err, errExecFailed)
+	}
+}
@@ -0,0 +1,147 @@
+// Package models holds the plain data types xo's loaders populate during
+// schema introspection, plus the XODB interface loaders run queries
+// against and a handful of shared postgres introspection helpers.
+package models
+
+import "database/sql"
+
+// XODB is the common interface for database operations that can be used
+// with types from schema/query introspection.
+type XODB interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// Logger, if non-nil, receives every query run by the loaders (query text
+// followed by its positional args). Set by the xo command when -v is
+// passed; nil (the default) disables logging.
+var Logger func(string, ...interface{})
+
+// XOLog logs a query, if a Logger has been set.
+func XOLog(s string, v ...interface{}) {
+	if Logger != nil {
+		Logger(s, v...)
+	}
+}
+
+// Column represents a table, view, or query result column.
+type Column struct {
+	FieldOrdinal int
+	ColumnName   string
+	DataType     string
+	NotNull      bool
+	IsPrimaryKey bool
+}
+
+// Table represents a table or view.
+type Table struct {
+	TableName string
+	Type      string
+	ManualPk  bool
+
+	// PartitionKeyColumns and ParentTable are only set when the table is
+	// a partitioned table (Type == "p") or one of its partitions; they
+	// come from PgPartitionedTables via pg_partitioned_table/pg_inherits.
+	// Nothing in this tree yet consumes them to generate code -- parent-
+	// routed lookups for partitions, and a RefreshX helper for
+	// materialized views, are both still outstanding codegen work; this
+	// is introspection groundwork only so far.
+	PartitionKeyColumns []string
+	ParentTable         string
+}
+
+// Sequence represents a sequence associated with a table.
+type Sequence struct {
+	TableName    string
+	SequenceName string
+}
+
+// Enum represents a Postgres enum type.
+type Enum struct {
+	EnumName string
+}
+
+// EnumValue represents a single value of a Postgres enum type.
+type EnumValue struct {
+	EnumName  string
+	ConstName string
+	ConstVal  int
+}
+
+// Proc represents a stored procedure or function.
+type Proc struct {
+	ProcName     string
+	ReturnType   string
+	ReturnTypeID string
+}
+
+// ProcParam represents a single parameter of a stored procedure or
+// function.
+type ProcParam struct {
+	ProcName  string
+	ParamType string
+}
+
+// ForeignKey represents a foreign key constraint on a table.
+type ForeignKey struct {
+	FKName       string
+	ColumnName   string
+	RefTableName string
+	RefColName   string
+}
+
+// Index represents an index on a table.
+type Index struct {
+	IndexName string
+	IsUnique  bool
+	IsPrimary bool
+}
+
+// IndexColumn represents a single column of an index, in index-key order.
+type IndexColumn struct {
+	SeqNo      int
+	Cid        int
+	ColumnName string
+
+	// PartitionScope is set by PgIndexColumns/pgIndexPartitionScope to
+	// one of the PartitionScope* constants, describing whether this
+	// index is local to one partition or spans a partitioned table via
+	// matching child indexes. Nothing yet consumes it to generate
+	// parent-routed FindByX/FindByX_InPartition lookups -- it's
+	// introspection groundwork only so far.
+	PartitionScope string
+}
+
+// ColOrder holds the space-separated column id order of an index, as
+// reported by pg_index.indkey.
+type ColOrder struct {
+	Ord string
+}
+
+// Domain represents a Postgres domain (CREATE DOMAIN).
+type Domain struct {
+	DomainName string
+	BaseType   string
+}
+
+// Composite represents a Postgres composite type (CREATE TYPE ... AS (...)).
+type Composite struct {
+	TypeName string
+}
+
+// CompositeAttr represents a single attribute of a composite type.
+type CompositeAttr struct {
+	TypeName  string
+	AttrName  string
+	DataType  string
+	AttrOrder int
+}
+
+// Partition represents a partitioned table's partition key columns and,
+// for a partition itself, the parent table it was attached to.
+type Partition struct {
+	TableName   string
+	ParentTable string
+	KeyColumns  []string
+}
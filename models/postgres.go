@@ -0,0 +1,546 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// PgColumnsFromFields builds the Column list for a prepared statement's
+// result fields, resolving each field's DataTypeOID against pg_type (via
+// format_type) on the same connection the statement was prepared on.
+func PgColumnsFromFields(ctx context.Context, conn *pgx.Conn, fields []pgconn.FieldDescription) ([]*Column, error) {
+	res := make([]*Column, len(fields))
+	for i, f := range fields {
+		var dataType string
+		XOLog("SELECT format_type($1, NULL)", f.DataTypeOID)
+		err := conn.QueryRow(ctx, `SELECT format_type($1, NULL)`, f.DataTypeOID).Scan(&dataType)
+		if err != nil {
+			return nil, err
+		}
+		res[i] = &Column{
+			FieldOrdinal: i + 1,
+			ColumnName:   string(f.Name),
+			DataType:     dataType,
+		}
+	}
+	return res, nil
+}
+
+// PgTables returns the tables or views (depending on relkind) in schema.
+func PgTables(db XODB, schema string, relkind string) ([]*Table, error) {
+	const sqlstr = `SELECT c.relname AS table_name ` +
+		`FROM pg_class c ` +
+		`JOIN pg_namespace n ON n.oid = c.relnamespace ` +
+		`WHERE n.nspname = $1 AND c.relkind = $2 ` +
+		`ORDER BY c.relname`
+
+	XOLog(sqlstr, schema, relkind)
+	rows, err := db.Query(sqlstr, schema, relkind)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*Table
+	for rows.Next() {
+		t := &Table{Type: relkind}
+		if err := rows.Scan(&t.TableName); err != nil {
+			return nil, err
+		}
+		res = append(res, t)
+	}
+	return res, rows.Err()
+}
+
+// PgSequences returns the sequences owned by a table column in schema.
+func PgSequences(db XODB, schema string) ([]*Sequence, error) {
+	const sqlstr = `SELECT t.relname AS table_name, s.relname AS sequence_name ` +
+		`FROM pg_class s ` +
+		`JOIN pg_depend d ON d.objid = s.oid AND d.deptype = 'a' ` +
+		`JOIN pg_class t ON t.oid = d.refobjid ` +
+		`JOIN pg_namespace n ON n.oid = s.relnamespace ` +
+		`WHERE n.nspname = $1 AND s.relkind = 'S'`
+
+	XOLog(sqlstr, schema)
+	rows, err := db.Query(sqlstr, schema)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*Sequence
+	for rows.Next() {
+		s := &Sequence{}
+		if err := rows.Scan(&s.TableName, &s.SequenceName); err != nil {
+			return nil, err
+		}
+		res = append(res, s)
+	}
+	return res, rows.Err()
+}
+
+// PgTableColumns returns the columns for table in schema. When
+// enableOIDs is true, the column's Postgres type OID is recorded as its
+// DataType instead of the type's name.
+func PgTableColumns(db XODB, schema string, table string, enableOIDs bool) ([]*Column, error) {
+	dtExpr := "format_type(a.atttypid, a.atttypmod)"
+	if enableOIDs {
+		dtExpr = "a.atttypid::text"
+	}
+
+	sqlstr := `SELECT a.attnum AS field_ordinal, a.attname AS column_name, ` +
+		dtExpr + ` AS data_type, a.attnotnull AS not_null, ` +
+		`EXISTS ( ` +
+		`  SELECT 1 FROM pg_index i ` +
+		`  WHERE i.indrelid = c.oid AND i.indisprimary AND a.attnum = ANY(i.indkey) ` +
+		`) AS is_primary_key ` +
+		`FROM pg_attribute a ` +
+		`JOIN pg_class c ON c.oid = a.attrelid ` +
+		`JOIN pg_namespace n ON n.oid = c.relnamespace ` +
+		`WHERE n.nspname = $1 AND c.relname = $2 AND a.attnum > 0 AND NOT a.attisdropped ` +
+		`ORDER BY a.attnum`
+
+	XOLog(sqlstr, schema, table)
+	rows, err := db.Query(sqlstr, schema, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*Column
+	for rows.Next() {
+		c := &Column{}
+		if err := rows.Scan(&c.FieldOrdinal, &c.ColumnName, &c.DataType, &c.NotNull, &c.IsPrimaryKey); err != nil {
+			return nil, err
+		}
+		res = append(res, c)
+	}
+	return res, rows.Err()
+}
+
+// PgTableForeignKeys returns the foreign keys defined on table in schema.
+func PgTableForeignKeys(db XODB, schema string, table string) ([]*ForeignKey, error) {
+	const sqlstr = `SELECT con.conname AS fk_name, ` +
+		`a.attname AS column_name, ` +
+		`rt.relname AS ref_table_name, ` +
+		`ra.attname AS ref_col_name ` +
+		`FROM pg_constraint con ` +
+		`JOIN pg_class c ON c.oid = con.conrelid ` +
+		`JOIN pg_namespace n ON n.oid = c.relnamespace ` +
+		`JOIN pg_attribute a ON a.attrelid = c.oid AND a.attnum = ANY(con.conkey) ` +
+		`JOIN pg_class rt ON rt.oid = con.confrelid ` +
+		`JOIN pg_attribute ra ON ra.attrelid = rt.oid AND ra.attnum = ANY(con.confkey) ` +
+		`WHERE n.nspname = $1 AND c.relname = $2 AND con.contype = 'f'`
+
+	XOLog(sqlstr, schema, table)
+	rows, err := db.Query(sqlstr, schema, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*ForeignKey
+	for rows.Next() {
+		fk := &ForeignKey{}
+		if err := rows.Scan(&fk.FKName, &fk.ColumnName, &fk.RefTableName, &fk.RefColName); err != nil {
+			return nil, err
+		}
+		res = append(res, fk)
+	}
+	return res, rows.Err()
+}
+
+// PgTableIndexes returns the indexes defined on table in schema.
+func PgTableIndexes(db XODB, schema string, table string) ([]*Index, error) {
+	const sqlstr = `SELECT ic.relname AS index_name, i.indisunique AS is_unique, i.indisprimary AS is_primary ` +
+		`FROM pg_index i ` +
+		`JOIN pg_class c ON c.oid = i.indrelid ` +
+		`JOIN pg_class ic ON ic.oid = i.indexrelid ` +
+		`JOIN pg_namespace n ON n.oid = c.relnamespace ` +
+		`WHERE n.nspname = $1 AND c.relname = $2`
+
+	XOLog(sqlstr, schema, table)
+	rows, err := db.Query(sqlstr, schema, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*Index
+	for rows.Next() {
+		idx := &Index{}
+		if err := rows.Scan(&idx.IndexName, &idx.IsUnique, &idx.IsPrimary); err != nil {
+			return nil, err
+		}
+		res = append(res, idx)
+	}
+	return res, rows.Err()
+}
+
+// PgIndexColumns returns the (unordered) columns of index in schema.
+func PgIndexColumns(db XODB, schema string, index string) ([]*IndexColumn, error) {
+	const sqlstr = `SELECT a.attnum AS cid, a.attname AS column_name ` +
+		`FROM pg_attribute a ` +
+		`JOIN pg_class c ON c.oid = a.attrelid ` +
+		`JOIN pg_namespace n ON n.oid = c.relnamespace ` +
+		`WHERE n.nspname = $1 AND c.relname = $2 AND a.attnum > 0`
+
+	XOLog(sqlstr, schema, index)
+	rows, err := db.Query(sqlstr, schema, index)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*IndexColumn
+	for rows.Next() {
+		ic := &IndexColumn{}
+		if err := rows.Scan(&ic.Cid, &ic.ColumnName); err != nil {
+			return nil, err
+		}
+		res = append(res, ic)
+	}
+	return res, rows.Err()
+}
+
+// PgGetColOrder returns the space-separated column id order of index in
+// schema, as reported by pg_index.indkey.
+func PgGetColOrder(db XODB, schema string, index string) (*ColOrder, error) {
+	const sqlstr = `SELECT array_to_string(i.indkey, ' ') AS ord ` +
+		`FROM pg_index i ` +
+		`JOIN pg_class c ON c.oid = i.indexrelid ` +
+		`JOIN pg_namespace n ON n.oid = c.relnamespace ` +
+		`WHERE n.nspname = $1 AND c.relname = $2`
+
+	XOLog(sqlstr, schema, index)
+	co := &ColOrder{}
+	err := db.QueryRow(sqlstr, schema, index).Scan(&co.Ord)
+	if err != nil {
+		return nil, err
+	}
+	return co, nil
+}
+
+// PgEnums returns the enum types defined in schema.
+func PgEnums(db XODB, schema string) ([]*Enum, error) {
+	const sqlstr = `SELECT DISTINCT t.typname AS enum_name ` +
+		`FROM pg_type t ` +
+		`JOIN pg_namespace n ON n.oid = t.typnamespace ` +
+		`JOIN pg_enum e ON e.enumtypid = t.oid ` +
+		`WHERE n.nspname = $1`
+
+	XOLog(sqlstr, schema)
+	rows, err := db.Query(sqlstr, schema)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*Enum
+	for rows.Next() {
+		en := &Enum{}
+		if err := rows.Scan(&en.EnumName); err != nil {
+			return nil, err
+		}
+		res = append(res, en)
+	}
+	return res, rows.Err()
+}
+
+// PgEnumValues returns the values of every enum type defined in schema.
+func PgEnumValues(db XODB, schema string) ([]*EnumValue, error) {
+	const sqlstr = `SELECT t.typname AS enum_name, e.enumlabel AS const_name, e.enumsortorder::int AS const_val ` +
+		`FROM pg_type t ` +
+		`JOIN pg_namespace n ON n.oid = t.typnamespace ` +
+		`JOIN pg_enum e ON e.enumtypid = t.oid ` +
+		`WHERE n.nspname = $1 ` +
+		`ORDER BY t.typname, e.enumsortorder`
+
+	XOLog(sqlstr, schema)
+	rows, err := db.Query(sqlstr, schema)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*EnumValue
+	for rows.Next() {
+		ev := &EnumValue{}
+		if err := rows.Scan(&ev.EnumName, &ev.ConstName, &ev.ConstVal); err != nil {
+			return nil, err
+		}
+		res = append(res, ev)
+	}
+	return res, rows.Err()
+}
+
+// PgProcs returns the stored procedures/functions defined in schema.
+func PgProcs(db XODB, schema string) ([]*Proc, error) {
+	const sqlstr = `SELECT p.proname AS proc_name, ` +
+		`format_type(p.prorettype, NULL) AS return_type, p.prorettype::text AS return_type_id ` +
+		`FROM pg_proc p ` +
+		`JOIN pg_namespace n ON n.oid = p.pronamespace ` +
+		`WHERE n.nspname = $1`
+
+	XOLog(sqlstr, schema)
+	rows, err := db.Query(sqlstr, schema)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*Proc
+	for rows.Next() {
+		p := &Proc{}
+		if err := rows.Scan(&p.ProcName, &p.ReturnType, &p.ReturnTypeID); err != nil {
+			return nil, err
+		}
+		res = append(res, p)
+	}
+	return res, rows.Err()
+}
+
+// PgProcParams returns the parameters of every stored procedure/function
+// defined in schema.
+func PgProcParams(db XODB, schema string) ([]*ProcParam, error) {
+	const sqlstr = `SELECT p.proname AS proc_name, ` +
+		`format_type(unnest(p.proargtypes), NULL) AS param_type ` +
+		`FROM pg_proc p ` +
+		`JOIN pg_namespace n ON n.oid = p.pronamespace ` +
+		`WHERE n.nspname = $1`
+
+	XOLog(sqlstr, schema)
+	rows, err := db.Query(sqlstr, schema)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*ProcParam
+	for rows.Next() {
+		pp := &ProcParam{}
+		if err := rows.Scan(&pp.ProcName, &pp.ParamType); err != nil {
+			return nil, err
+		}
+		res = append(res, pp)
+	}
+	return res, rows.Err()
+}
+
+// PgDomains returns the domains (typtype = 'd') defined in schema, along
+// with each domain's base type.
+func PgDomains(db XODB, schema string) ([]*Domain, error) {
+	const sqlstr = `SELECT t.typname AS domain_name, format_type(t.typbasetype, t.typtypmod) AS base_type ` +
+		`FROM pg_type t ` +
+		`JOIN pg_namespace n ON n.oid = t.typnamespace ` +
+		`WHERE n.nspname = $1 AND t.typtype = 'd'`
+
+	XOLog(sqlstr, schema)
+	rows, err := db.Query(sqlstr, schema)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*Domain
+	for rows.Next() {
+		d := &Domain{}
+		if err := rows.Scan(&d.DomainName, &d.BaseType); err != nil {
+			return nil, err
+		}
+		res = append(res, d)
+	}
+	return res, rows.Err()
+}
+
+// PgComposites returns the composite types (typtype = 'c') defined in
+// schema.
+func PgComposites(db XODB, schema string) ([]*Composite, error) {
+	const sqlstr = `SELECT t.typname AS type_name ` +
+		`FROM pg_type t ` +
+		`JOIN pg_namespace n ON n.oid = t.typnamespace ` +
+		`WHERE n.nspname = $1 AND t.typtype = 'c'`
+
+	XOLog(sqlstr, schema)
+	rows, err := db.Query(sqlstr, schema)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*Composite
+	for rows.Next() {
+		c := &Composite{}
+		if err := rows.Scan(&c.TypeName); err != nil {
+			return nil, err
+		}
+		res = append(res, c)
+	}
+	return res, rows.Err()
+}
+
+// PgCompositeAttrs returns the attributes of composite type typeName in
+// schema, in declaration order.
+func PgCompositeAttrs(db XODB, schema string, typeName string) ([]*CompositeAttr, error) {
+	const sqlstr = `SELECT t.typname AS type_name, a.attname AS attr_name, ` +
+		`format_type(a.atttypid, a.atttypmod) AS data_type, a.attnum AS attr_order ` +
+		`FROM pg_attribute a ` +
+		`JOIN pg_type t ON t.typrelid = a.attrelid ` +
+		`JOIN pg_namespace n ON n.oid = t.typnamespace ` +
+		`WHERE n.nspname = $1 AND t.typname = $2 AND a.attnum > 0 AND NOT a.attisdropped ` +
+		`ORDER BY a.attnum`
+
+	XOLog(sqlstr, schema, typeName)
+	rows, err := db.Query(sqlstr, schema, typeName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*CompositeAttr
+	for rows.Next() {
+		a := &CompositeAttr{}
+		if err := rows.Scan(&a.TypeName, &a.AttrName, &a.DataType, &a.AttrOrder); err != nil {
+			return nil, err
+		}
+		res = append(res, a)
+	}
+	return res, rows.Err()
+}
+
+// PgPartitionedTables returns a Partition entry for every table in schema
+// that is either a partitioned parent (with its partition key columns, via
+// pg_partitioned_table) or a partition attached to one (with its parent
+// table name, via pg_inherits).
+func PgPartitionedTables(db XODB, schema string) ([]*Partition, error) {
+	partitions := map[string]*Partition{}
+
+	const parentq = `SELECT c.relname AS table_name, ` +
+		`(SELECT string_agg(a.attname, ',' ORDER BY k.ord) ` +
+		`   FROM unnest(pt.partattrs) WITH ORDINALITY AS k(attnum, ord) ` +
+		`   JOIN pg_attribute a ON a.attrelid = c.oid AND a.attnum = k.attnum) AS key_columns ` +
+		`FROM pg_partitioned_table pt ` +
+		`JOIN pg_class c ON c.oid = pt.partrelid ` +
+		`JOIN pg_namespace n ON n.oid = c.relnamespace ` +
+		`WHERE n.nspname = $1`
+
+	XOLog(parentq, schema)
+	rows, err := db.Query(parentq, schema)
+	if err != nil {
+		return nil, err
+	}
+	func() {
+		defer rows.Close()
+		for rows.Next() {
+			var tableName string
+			var keyColumns sql.NullString
+			if err = rows.Scan(&tableName, &keyColumns); err != nil {
+				return
+			}
+			p := &Partition{TableName: tableName}
+			if keyColumns.Valid && keyColumns.String != "" {
+				p.KeyColumns = strings.Split(keyColumns.String, ",")
+			}
+			partitions[tableName] = p
+		}
+		err = rows.Err()
+	}()
+	if err != nil {
+		return nil, err
+	}
+
+	const childq = `SELECT c.relname AS table_name, p.relname AS parent_table ` +
+		`FROM pg_inherits i ` +
+		`JOIN pg_class c ON c.oid = i.inhrelid ` +
+		`JOIN pg_class p ON p.oid = i.inhparent ` +
+		`JOIN pg_partitioned_table pt ON pt.partrelid = p.oid ` +
+		`JOIN pg_namespace n ON n.oid = c.relnamespace ` +
+		`WHERE n.nspname = $1`
+
+	XOLog(childq, schema)
+	rows, err = db.Query(childq, schema)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var tableName, parentTable string
+		if err := rows.Scan(&tableName, &parentTable); err != nil {
+			return nil, err
+		}
+		p, ok := partitions[tableName]
+		if !ok {
+			p = &Partition{TableName: tableName}
+			partitions[tableName] = p
+		}
+		p.ParentTable = parentTable
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	res := make([]*Partition, 0, len(partitions))
+	for _, p := range partitions {
+		res = append(res, p)
+	}
+	return res, nil
+}
+
+// PgInheritedChildren returns the immediate pg_inherits children of table
+// in schema.
+func PgInheritedChildren(db XODB, schema, table string) ([]string, error) {
+	const sqlstr = `SELECT c.relname ` +
+		`FROM pg_inherits i ` +
+		`JOIN pg_class c ON c.oid = i.inhrelid ` +
+		`JOIN pg_class p ON p.oid = i.inhparent ` +
+		`JOIN pg_namespace n ON n.oid = p.relnamespace ` +
+		`WHERE n.nspname = $1 AND p.relname = $2`
+
+	XOLog(sqlstr, schema, table)
+	rows, err := db.Query(sqlstr, schema, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		res = append(res, name)
+	}
+	return res, rows.Err()
+}
+
+// PgIndexAttachedOnAllPartitions reports whether index, declared on the
+// partitioned parent table, has a matching valid index (via pg_inherits
+// over the index relations, per pg_index.indisvalid) attached on every
+// partition of table -- i.e. whether it behaves as a "global-style" index
+// rather than one local to a single partition.
+func PgIndexAttachedOnAllPartitions(db XODB, schema, table, index string) (bool, error) {
+	const sqlstr = `SELECT ` +
+		`(SELECT count(*) FROM pg_inherits ti JOIN pg_class tc ON tc.oid = ti.inhrelid ` +
+		`   JOIN pg_class tp ON tp.oid = ti.inhparent JOIN pg_namespace tn ON tn.oid = tp.relnamespace ` +
+		`   WHERE tn.nspname = $1 AND tp.relname = $2) AS partition_count, ` +
+		`(SELECT count(*) FROM pg_inherits ii ` +
+		`   JOIN pg_class ic ON ic.oid = ii.inhrelid ` +
+		`   JOIN pg_index i ON i.indexrelid = ii.inhrelid AND i.indisvalid ` +
+		`   JOIN pg_class pic ON pic.oid = ii.inhparent ` +
+		`   JOIN pg_namespace pn ON pn.oid = pic.relnamespace ` +
+		`   WHERE pn.nspname = $1 AND pic.relname = $3) AS attached_count`
+
+	var partitionCount, attachedCount int
+	XOLog(sqlstr, schema, table, index)
+	err := db.QueryRow(sqlstr, schema, table, index).Scan(&partitionCount, &attachedCount)
+	if err != nil {
+		return false, err
+	}
+
+	return partitionCount > 0 && attachedCount >= partitionCount, nil
+}